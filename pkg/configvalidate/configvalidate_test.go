@@ -16,12 +16,14 @@ package configvalidate
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -128,3 +130,71 @@ func TestHandle(t *testing.T) {
 		})
 	}
 }
+
+func FuzzHandle(f *testing.F) {
+	for _, seed := range []string{
+		`{"prometheusK8s": {}}`,
+		`{"prometheus_operator": {}}`,
+		`prometheusK8s:
+  retention: 1d
+  remoteWrite:
+  - url: http://example.com
+    writeRelabelConfigs:
+    - sourceLabels: [__name__]
+      regex: up
+      action: keep`,
+		`alertmanager:
+  enabled: true
+  enableAlertmanagerConfig: true`,
+		"",
+		"- foo",
+		"# empty",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, config string) {
+		for _, cm := range []struct {
+			namespace string
+			name      string
+		}{
+			{namespace: monitoringPlatformNamespace, name: monitoringPlatformConfigmap},
+			{namespace: monitoringUWMNamespace, name: monitoringUWMConfigmap},
+		} {
+			raw, err := json.Marshal(corev1.ConfigMap{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cm.name,
+					Namespace: cm.namespace,
+				},
+				Data: map[string]string{
+					"config.yaml": config,
+				},
+			})
+			require.NoError(t, err)
+
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Name:      cm.name,
+					Namespace: cm.namespace,
+					Resource: metav1.GroupVersionResource{
+						Group:    "",
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Object: runtime.RawExtension{Raw: raw},
+				},
+			}
+
+			// The validator must never panic and must always return a
+			// decision.
+			res := newConfigmapsValidator().Handle(context.Background(), req)
+			if !res.Allowed {
+				require.NotNil(t, res.Result)
+			}
+		}
+	})
+}
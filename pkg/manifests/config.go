@@ -725,7 +725,7 @@ func NewUserConfigFromString(content string) (*UserWorkloadConfiguration, error)
 		return NewDefaultUserWorkloadMonitoringConfig(), nil
 	}
 	u := &UserWorkloadConfiguration{}
-	err := UnmarshalStrict([]byte(content), &u)
+	err := UnmarshalStrict([]byte(content), u)
 	if err != nil {
 		return nil, err
 	}
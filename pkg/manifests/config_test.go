@@ -261,6 +261,24 @@ thanosRuler:
 				return ``
 			},
 		},
+		{
+			name: "yaml string with comments only",
+			configString: func() string {
+				return `# nothing to configure`
+			},
+			configCheck: func(uwmc *UserWorkloadConfiguration) {
+				require.NotNil(t, uwmc.Prometheus)
+			},
+		},
+		{
+			name: "null yaml string",
+			configString: func() string {
+				return `null`
+			},
+			configCheck: func(uwmc *UserWorkloadConfiguration) {
+				require.NotNil(t, uwmc.Prometheus)
+			},
+		},
 	}
 
 	for _, tc := range tcs {
@@ -18,18 +18,25 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
+	"text/tabwriter"
 	"time"
 
 	"github.com/Jeffail/gabs"
 	routev1 "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+	"github.com/prometheus/common/model"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/transport"
 )
 
 // PrometheusClient provides access to the Prometheus, Thanos & Alertmanager API.
@@ -48,13 +55,14 @@ func NewPrometheusClientFromRoute(
 	routeClient routev1.RouteV1Interface,
 	namespace, name string,
 	token string,
+	wts ...WrapTransporter,
 ) (*PrometheusClient, error) {
 	route, err := routeClient.Routes(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 
-	return NewPrometheusClient(route.Spec.Host, token), nil
+	return NewPrometheusClient(route.Spec.Host, token, wts...), nil
 }
 
 // WrapTransporter wraps an http.RoundTripper with another.
@@ -63,12 +71,27 @@ type WrapTransporter interface {
 }
 
 // NewPrometheusClient creates and returns a new PrometheusClient.
+// The User-Agent header defaults to E2eUserAgent and can be overridden with a
+// UserAgentInjector.
 func NewPrometheusClient(host, token string, wts ...WrapTransporter) *PrometheusClient {
+	return newPrometheusClient(host, &HeaderInjector{Name: "Authorization", Value: "Bearer " + token}, wts...)
+}
+
+// NewPrometheusClientWithTokenFile creates and returns a new PrometheusClient
+// which reads the bearer token from a file such as a projected service
+// account token. It is an alternative to NewPrometheusClient for
+// environments where the TokenRequest API isn't available.
+func NewPrometheusClientWithTokenFile(host, tokenFile string, wts ...WrapTransporter) *PrometheusClient {
+	return newPrometheusClient(host, &TokenFileInjector{Path: tokenFile}, wts...)
+}
+
+func newPrometheusClient(host string, auth WrapTransporter, wts ...WrapTransporter) *PrometheusClient {
 	// #nosec
 	var rt http.RoundTripper = &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	rt = (&HeaderInjector{Name: "Authorization", Value: "Bearer " + token}).WrapTransport(rt)
+	rt = (&UserAgentInjector{UserAgent: E2eUserAgent}).WrapTransport(rt)
+	rt = auth.WrapTransport(rt)
 	rt = (&HeaderInjector{Name: "Content-Type", Value: "application/json"}).WrapTransport(rt)
 	for i := range wts {
 		rt = wts[i].WrapTransport(rt)
@@ -130,6 +153,38 @@ func (h *HeaderInjector) WrapTransport(rt http.RoundTripper) http.RoundTripper {
 	)
 }
 
+// TokenFileInjector injects the bearer token read from a file into the
+// inbound request. The token is cached and re-read every minute or after
+// receiving a 401 response, so that rotated tokens are picked up without
+// recreating the client.
+type TokenFileInjector struct {
+	Path string
+}
+
+// WrapTransport implements the WrapTransporter interface.
+func (tf *TokenFileInjector) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return transport.ResettableTokenSourceWrapTransport(transport.NewCachedFileTokenSource(tf.Path))(rt)
+}
+
+// UserAgentInjector sets the User-Agent header of the inbound request unless
+// it has already been set by an outer transport. It allows Prometheus and
+// API server access logs to identify the subsystem issuing the requests.
+type UserAgentInjector struct {
+	UserAgent string
+}
+
+// WrapTransport implements the WrapTransporter interface.
+func (ua *UserAgentInjector) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("User-Agent") == "" {
+				req.Header.Set("User-Agent", ua.UserAgent)
+			}
+			return rt.RoundTrip(req)
+		},
+	)
+}
+
 // QueryParameterInjector injects a fixed query parameter into the inbound request.
 // It is typically used when querying kube-rbac-proxy.
 type QueryParameterInjector struct {
@@ -149,6 +204,79 @@ func (qp *QueryParameterInjector) WrapTransport(rt http.RoundTripper) http.Round
 	)
 }
 
+// ErrResponseTooLarge is returned when reading a response body which exceeds
+// the limit configured by ResponseSizeLimiter.
+type ErrResponseTooLarge struct {
+	Limit int64
+}
+
+func (e *ErrResponseTooLarge) Error() string {
+	return fmt.Sprintf("response body exceeds the maximum size of %d bytes", e.Limit)
+}
+
+// IsResponseTooLarge returns true if the error (or any error it wraps) is an
+// ErrResponseTooLarge error.
+func IsResponseTooLarge(err error) bool {
+	var e *ErrResponseTooLarge
+	return errors.As(err, &e)
+}
+
+// ResponseSizeLimiter caps the number of bytes which can be read from the
+// response body. It protects the caller against pathological queries which
+// would otherwise load hundreds of megabytes in memory.
+type ResponseSizeLimiter struct {
+	MaxBytes int64
+}
+
+// WrapTransport implements the WrapTransporter interface.
+func (l *ResponseSizeLimiter) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			resp, err := rt.RoundTrip(req)
+			if err != nil || l.MaxBytes <= 0 {
+				return resp, err
+			}
+
+			if resp.ContentLength > l.MaxBytes {
+				_ = resp.Body.Close()
+				return nil, &ErrResponseTooLarge{Limit: l.MaxBytes}
+			}
+
+			resp.Body = &limitedReadCloser{ReadCloser: resp.Body, limit: l.MaxBytes, remaining: l.MaxBytes}
+			return resp, nil
+		},
+	)
+}
+
+// limitedReadCloser behaves like io.LimitedReader except that it returns an
+// ErrResponseTooLarge error instead of io.EOF when the underlying reader has
+// more data than allowed.
+type limitedReadCloser struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func (r *limitedReadCloser) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		// Probe for one more byte to distinguish a body which is exactly
+		// at the limit from one which goes over it.
+		var b [1]byte
+		n, err := r.ReadCloser.Read(b[:])
+		if n > 0 {
+			return 0, &ErrResponseTooLarge{Limit: r.limit}
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
+
 // PrometheusQuery runs an HTTP GET request against the Prometheus query API and returns
 // the response body.
 func (c *PrometheusClient) PrometheusQuery(query string) ([]byte, error) {
@@ -174,6 +302,61 @@ func (c *PrometheusClient) PrometheusQueryWithStatus(query string, status int) (
 	return body, nil
 }
 
+// PrometheusQueryRange runs an HTTP GET request against the Prometheus range
+// query API and returns the response body. Additional query parameters can be
+// passed as key-value pairs.
+func (c *PrometheusClient) PrometheusQueryRange(query string, start, end time.Time, step time.Duration, kvs ...string) ([]byte, error) {
+	return c.get(
+		"/api/v1/query_range",
+		append([]string{
+			"query", query,
+			"start", start.Format(time.RFC3339Nano),
+			"end", end.Format(time.RFC3339Nano),
+			"step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64),
+		}, kvs...)...,
+	)
+}
+
+// The Thanos compactor downsamples blocks older than 40 hours to a 5m
+// resolution and blocks older than 10 days to a 1h resolution.
+const (
+	downsampling5mWindow = 40 * time.Hour
+	downsampling1hWindow = 10 * 24 * time.Hour
+)
+
+// downsampledResolution returns the coarsest Thanos resolution which can be
+// used for a range query covering the given window.
+func downsampledResolution(window time.Duration) time.Duration {
+	switch {
+	case window > downsampling1hWindow:
+		return time.Hour
+	case window > downsampling5mWindow:
+		return 5 * time.Minute
+	default:
+		return 0
+	}
+}
+
+// DownsampledQueryRange runs a range query which reads downsampled data
+// when the window is long enough for Thanos to have it, so that queries
+// spanning days or weeks complete in reasonable time. The step is raised to
+// the selected resolution if needed.
+//
+// The max_source_resolution parameter is ignored by Prometheus which means
+// that the query falls back to the raw resolution when the client doesn't
+// target Thanos Querier.
+func (c *PrometheusClient) DownsampledQueryRange(query string, start, end time.Time, step time.Duration) ([]byte, error) {
+	resolution := downsampledResolution(end.Sub(start))
+	if step < resolution {
+		step = resolution
+	}
+
+	return c.PrometheusQueryRange(
+		query, start, end, step,
+		"max_source_resolution", model.Duration(resolution).String(),
+	)
+}
+
 // PrometheusTargets runs an HTTP GET request against the Prometheus targets API and returns
 // the response body.
 func (c *PrometheusClient) PrometheusTargets() ([]byte, error) {
@@ -246,23 +429,131 @@ func (c *PrometheusClient) PrometheusLabel(label string) ([]byte, error) {
 // GetAlertmanagerAlerts runs an HTTP GET request against the Alertmanager
 // /api/v2/alerts endpoint and returns the response body.
 func (c *PrometheusClient) GetAlertmanagerAlerts(kvs ...string) ([]byte, error) {
-	return c.getAlertmanager("/api/v2/alerts", kvs...)
+	return c.get("/api/v2/alerts", kvs...)
+}
+
+// AlertmanagerAlertsFilter holds the query parameters supported by the
+// Alertmanager /api/v2/alerts endpoint. Nil booleans use the Alertmanager
+// defaults.
+type AlertmanagerAlertsFilter struct {
+	// Matchers are label matchers such as `alertname="Watchdog"`.
+	Matchers []string
+	// Receiver is a regular expression matching the receiver names.
+	Receiver    string
+	Active      *bool
+	Silenced    *bool
+	Inhibited   *bool
+	Unprocessed *bool
+}
+
+// KeyValues returns the filter as key-value pairs which can be passed to
+// GetAlertmanagerAlerts.
+func (f AlertmanagerAlertsFilter) KeyValues() []string {
+	var kvs []string
+	for _, m := range f.Matchers {
+		kvs = append(kvs, "filter", m)
+	}
+
+	if f.Receiver != "" {
+		kvs = append(kvs, "receiver", f.Receiver)
+	}
+
+	for _, b := range []struct {
+		name  string
+		value *bool
+	}{
+		{name: "active", value: f.Active},
+		{name: "silenced", value: f.Silenced},
+		{name: "inhibited", value: f.Inhibited},
+		{name: "unprocessed", value: f.Unprocessed},
+	} {
+		if b.value != nil {
+			kvs = append(kvs, b.name, strconv.FormatBool(*b.value))
+		}
+	}
+
+	return kvs
+}
+
+// GetAlertmanagerAlertPages runs an HTTP GET request against the Alertmanager
+// /api/v2/alerts endpoint and decodes the response incrementally, calling fn
+// with pages of at most pageSize alerts. Contrary to GetAlertmanagerAlerts,
+// it doesn't load the full response in memory which matters during alert
+// storms. It stops and returns the error returned by fn, if any.
+func (c *PrometheusClient) GetAlertmanagerAlertPages(filter AlertmanagerAlertsFilter, pageSize int, fn func([]json.RawMessage) error) error {
+	if pageSize <= 0 {
+		return fmt.Errorf("invalid page size %d", pageSize)
+	}
+
+	path := "/api/v2/alerts"
+	resp, err := c.Do("GET", (&url.URL{Path: path, RawQuery: encodeKeyValues(filter.KeyValues()...)}).String(), nil)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: unexpected status code response, want %d, got %d (%q)", path, http.StatusOK, resp.StatusCode, ClampMax(body))
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	if tok, err := dec.Token(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("%s: expected a list of alerts, got %v", path, tok)
+	}
+
+	page := make([]json.RawMessage, 0, pageSize)
+	for dec.More() {
+		var alert json.RawMessage
+		if err := dec.Decode(&alert); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		page = append(page, alert)
+		if len(page) < pageSize {
+			continue
+		}
+
+		if err := fn(page); err != nil {
+			return err
+		}
+		page = make([]json.RawMessage, 0, pageSize)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(page) > 0 {
+		return fn(page)
+	}
+
+	return nil
 }
 
 // GetAlertmanagerSilences runs an HTTP GET request against the Alertmanager
 // /api/v2/silences endpoint and returns the response body.
 func (c *PrometheusClient) GetAlertmanagerSilences(kvs ...string) ([]byte, error) {
-	return c.getAlertmanager("/api/v2/silences", kvs...)
+	return c.get("/api/v2/silences", kvs...)
 }
 
-func (c *PrometheusClient) getAlertmanager(path string, kvs ...string) ([]byte, error) {
+func encodeKeyValues(kvs ...string) string {
 	q := make(url.Values)
 	for i := 0; i < len(kvs)/2; i++ {
 		q.Add(kvs[i*2], kvs[i*2+1])
 	}
+
+	return q.Encode()
+}
+
+func (c *PrometheusClient) get(path string, kvs ...string) ([]byte, error) {
 	u := url.URL{
 		Path:     path,
-		RawQuery: q.Encode(),
+		RawQuery: encodeKeyValues(kvs...),
 	}
 
 	resp, err := c.Do("GET", u.String(), nil)
@@ -321,6 +612,73 @@ func GetFirstValueFromPromQuery(body []byte) (float64, error) {
 	return v, nil
 }
 
+// GetWarningsFromPromResponse takes an API response body and returns the
+// warnings it contains. Thanos Querier reports partial responses (e.g. when a
+// store API is unavailable) as warnings.
+func GetWarningsFromPromResponse(body []byte) ([]string, error) {
+	var resp struct {
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Warnings, nil
+}
+
+// ErrPartialResponse is returned by clients configured with
+// StrictPartialResponse when the response contains warnings.
+type ErrPartialResponse struct {
+	Warnings []string
+}
+
+func (e *ErrPartialResponse) Error() string {
+	return fmt.Sprintf("partial response: %s", strings.Join(e.Warnings, "; "))
+}
+
+// StrictPartialResponse disables partial responses in Thanos Querier so that
+// queries fail instead of returning incomplete data during store outages.
+// Responses which still carry warnings are turned into ErrPartialResponse
+// errors.
+//
+// Only the Prometheus API endpoints (/api/v1/*) are affected, other requests
+// (e.g. to the Alertmanager API) pass through unchanged. Because the response
+// body is read in full to look for warnings, StrictPartialResponse should be
+// passed after ResponseSizeLimiter so that the size limit applies while the
+// body is buffered.
+type StrictPartialResponse struct{}
+
+// WrapTransport implements the WrapTransporter interface.
+func (StrictPartialResponse) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	strict := (&QueryParameterInjector{Name: "partial_response", Value: "false"}).WrapTransport(rt)
+
+	return roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, "/api/v1/") {
+				return rt.RoundTrip(req)
+			}
+
+			resp, err := strict.RoundTrip(req)
+			if err != nil || resp.StatusCode != http.StatusOK {
+				return resp, err
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			if warnings, _ := GetWarningsFromPromResponse(body); len(warnings) > 0 {
+				return nil, &ErrPartialResponse{Warnings: warnings}
+			}
+
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			return resp, nil
+		},
+	)
+}
+
 // GetResultSizeFromPromQuery takes a query api response body and returns the
 // size of the result vector.
 func GetResultSizeFromPromQuery(body []byte) (int, error) {
@@ -374,6 +732,8 @@ func (c *PrometheusClient) WaitForQueryReturn(t *testing.T, timeout time.Duratio
 			return fmt.Errorf("error getting response for query %q: %w", query, err)
 		}
 
+		logWarnings(t, query, body)
+
 		v, err := GetFirstValueFromPromQuery(body)
 		if err != nil {
 			return fmt.Errorf("error getting first value from response body %q for query %q: %w", string(body), query, err)
@@ -401,6 +761,8 @@ func (c *PrometheusClient) WaitForQueryReturnEmpty(t *testing.T, timeout time.Du
 			return fmt.Errorf("error getting response for query %q: %w", query, err)
 		}
 
+		logWarnings(t, query, body)
+
 		size, err := GetResultSizeFromPromQuery(body)
 		if err != nil {
 			return fmt.Errorf("error getting body size from body %q for query %q: %w", string(body), query, err)
@@ -463,3 +825,102 @@ func (c *PrometheusClient) WaitForTargetsReturn(t *testing.T, timeout time.Durat
 		t.Fatal(err)
 	}
 }
+
+// logWarnings logs the warnings contained in the query response (if any) so
+// that partial responses don't go unnoticed.
+func logWarnings(t *testing.T, query string, body []byte) {
+	t.Helper()
+
+	warnings, err := GetWarningsFromPromResponse(body)
+	if err != nil {
+		return
+	}
+
+	for _, w := range warnings {
+		t.Logf("warning for query %q: %s", query, w)
+	}
+}
+
+// AssertNoFiringAlertsExcept fails the test if any alert is firing besides
+// the allowed alert names (e.g. Watchdog). The failure message lists the
+// unexpected alerts as a table.
+func (c *PrometheusClient) AssertNoFiringAlertsExcept(t *testing.T, allowlist ...string) {
+	t.Helper()
+
+	query := `ALERTS{alertstate="firing"}`
+	body, err := c.PrometheusQuery(query)
+	if err != nil {
+		t.Fatalf("error getting response for query %q: %v", query, err)
+	}
+
+	alerts, err := unexpectedAlerts(body, allowlist)
+	if err != nil {
+		t.Fatalf("error parsing response body %q for query %q: %v", ClampMax(body), query, err)
+	}
+
+	if len(alerts) > 0 {
+		t.Errorf("found %d unexpected firing alert(s):\n%s", len(alerts), formatAlerts(alerts))
+	}
+}
+
+// unexpectedAlerts returns the label sets of the ALERTS series contained in
+// the query response whose alertname isn't in the allowlist, sorted by
+// alertname and namespace.
+func unexpectedAlerts(body []byte, allowlist []string) ([]map[string]string, error) {
+	var resp struct {
+		Data struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]struct{}, len(allowlist))
+	for _, name := range allowlist {
+		allowed[name] = struct{}{}
+	}
+
+	var alerts []map[string]string
+	for _, r := range resp.Data.Result {
+		if _, ok := allowed[r.Metric["alertname"]]; ok {
+			continue
+		}
+		alerts = append(alerts, r.Metric)
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i]["alertname"] != alerts[j]["alertname"] {
+			return alerts[i]["alertname"] < alerts[j]["alertname"]
+		}
+		return alerts[i]["namespace"] < alerts[j]["namespace"]
+	})
+
+	return alerts, nil
+}
+
+// formatAlerts renders the alerts as a table with one row per alert.
+func formatAlerts(alerts []map[string]string) string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALERTNAME\tNAMESPACE\tSEVERITY\tLABELS")
+	for _, a := range alerts {
+		var labels []string
+		for k, v := range a {
+			switch k {
+			case "__name__", "alertname", "alertstate", "namespace", "severity":
+				continue
+			}
+			labels = append(labels, fmt.Sprintf("%s=%q", k, v))
+		}
+		sort.Strings(labels)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a["alertname"], a["namespace"], a["severity"], strings.Join(labels, ","))
+	}
+	_ = w.Flush()
+
+	return b.String()
+}
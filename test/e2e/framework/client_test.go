@@ -15,7 +15,22 @@
 package framework
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/cluster-monitoring-operator/test/e2e/framework/fake"
 )
 
 func TestGetFirstValueFromPromQuery(t *testing.T) {
@@ -59,3 +74,435 @@ func TestGetFirstValueFromPromQuery(t *testing.T) {
 		t.Run(test.Name, test.F)
 	}
 }
+
+func newTestPrometheusClient(t *testing.T, h http.Handler, wts ...WrapTransporter) *PrometheusClient {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(h)
+	t.Cleanup(srv.Close)
+
+	return NewPrometheusClient(strings.TrimPrefix(srv.URL, "https://"), "", wts...)
+}
+
+func TestResponseSizeLimiter(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"vector","result":[]}}`
+
+	for _, tc := range []struct {
+		name     string
+		maxBytes int64
+		chunked  bool
+		tooLarge bool
+	}{
+		{
+			name:     "no limit",
+			maxBytes: 0,
+		},
+		{
+			name:     "body below the limit",
+			maxBytes: int64(len(body)) + 1,
+		},
+		{
+			name:     "content length equal to the limit",
+			maxBytes: int64(len(body)),
+		},
+		{
+			name:     "chunked body equal to the limit",
+			maxBytes: int64(len(body)),
+			chunked:  true,
+		},
+		{
+			name:     "content length above the limit",
+			maxBytes: 10,
+			tooLarge: true,
+		},
+		{
+			name:     "chunked body above the limit",
+			maxBytes: 10,
+			chunked:  true,
+			tooLarge: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestPrometheusClient(t,
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					if !tc.chunked {
+						w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+					}
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write([]byte(body))
+					if tc.chunked {
+						w.(http.Flusher).Flush()
+					}
+				}),
+				&ResponseSizeLimiter{MaxBytes: tc.maxBytes},
+			)
+
+			got, err := c.PrometheusQuery("up")
+			if tc.tooLarge {
+				if !IsResponseTooLarge(err) {
+					t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != body {
+				t.Fatalf("expected body %q, got %q", body, string(got))
+			}
+		})
+	}
+}
+
+func TestDownsampledQueryRange(t *testing.T) {
+	end := time.Now()
+
+	for _, tc := range []struct {
+		name       string
+		window     time.Duration
+		step       time.Duration
+		resolution string
+		expStep    string
+	}{
+		{
+			name:       "short window uses raw data",
+			window:     time.Hour,
+			step:       30 * time.Second,
+			resolution: "0s",
+			expStep:    "30",
+		},
+		{
+			name:       "window over 40 hours uses 5m resolution",
+			window:     3 * 24 * time.Hour,
+			step:       time.Minute,
+			resolution: "5m",
+			expStep:    "300",
+		},
+		{
+			name:       "window over 10 days uses 1h resolution",
+			window:     30 * 24 * time.Hour,
+			step:       2 * time.Hour,
+			resolution: "1h",
+			expStep:    "7200",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestPrometheusClient(t,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/api/v1/query_range" {
+						t.Errorf("unexpected path %q", r.URL.Path)
+					}
+
+					q := r.URL.Query()
+					if got := q.Get("max_source_resolution"); got != tc.resolution {
+						t.Errorf("expected max_source_resolution %q, got %q", tc.resolution, got)
+					}
+
+					if got := q.Get("step"); got != tc.expStep {
+						t.Errorf("expected step %q, got %q", tc.expStep, got)
+					}
+
+					_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+				}),
+			)
+
+			if _, err := c.DownsampledQueryRange("up", end.Add(-tc.window), end, tc.step); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestStrictPartialResponse(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		warnings []string
+		body     string
+	}{
+		{
+			name: "complete response",
+			body: `{"data":{"resultType":"vector","result":[]},"status":"success"}` + "\n",
+		},
+		{
+			name:     "partial response",
+			warnings: []string{"No StoreAPIs matched for this query"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := fake.NewServer()
+			t.Cleanup(srv.Close)
+			srv.SetScenario(fake.Scenario{Warnings: tc.warnings})
+
+			c := NewPrometheusClient(srv.Host(), "", StrictPartialResponse{})
+
+			got, err := c.PrometheusQuery("up")
+
+			reqs := srv.Requests()
+			if len(reqs) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(reqs))
+			}
+			if got := reqs[0].Query().Get("partial_response"); got != "false" {
+				t.Errorf("expected partial_response=false, got %q", got)
+			}
+
+			if len(tc.warnings) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(got) != tc.body {
+					t.Fatalf("expected body %q, got %q", tc.body, string(got))
+				}
+				return
+			}
+
+			var perr *ErrPartialResponse
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected ErrPartialResponse, got %v", err)
+			}
+
+			if !reflect.DeepEqual(perr.Warnings, tc.warnings) {
+				t.Fatalf("expected warnings %v, got %v", tc.warnings, perr.Warnings)
+			}
+		})
+	}
+}
+
+func TestStrictPartialResponseAlertmanager(t *testing.T) {
+	firstPage := make(chan struct{})
+	c := newTestPrometheusClient(t,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Has("partial_response") {
+				t.Errorf("expected no partial_response parameter, got %q", r.URL.Query().Get("partial_response"))
+			}
+
+			_, _ = w.Write([]byte(`[{"labels":{"alertname":"A1"}},`))
+			w.(http.Flusher).Flush()
+
+			// The rest of the body is only sent once the client has
+			// processed the first alert which fails if the response is
+			// buffered.
+			select {
+			case <-firstPage:
+			case <-time.After(5 * time.Second):
+				t.Error("expected the response body to be streamed")
+			}
+			_, _ = w.Write([]byte(`{"labels":{"alertname":"A2"}}]`))
+		}),
+		StrictPartialResponse{},
+	)
+
+	var n int
+	err := c.GetAlertmanagerAlertPages(AlertmanagerAlertsFilter{}, 1, func([]json.RawMessage) error {
+		n++
+		if n == 1 {
+			close(firstPage)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Fatalf("expected 2 pages, got %d", n)
+	}
+}
+
+func TestUnexpectedStatusCode(t *testing.T) {
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+	srv.SetScenario(fake.Scenario{StatusCode: http.StatusServiceUnavailable})
+
+	c := NewPrometheusClient(srv.Host(), "")
+
+	for name, fn := range map[string]func() ([]byte, error){
+		"query":               func() ([]byte, error) { return c.PrometheusQuery("up") },
+		"rules":               c.PrometheusRules,
+		"alertmanager alerts": func() ([]byte, error) { return c.GetAlertmanagerAlerts() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := fn(); err == nil || !strings.Contains(err.Error(), "got 503") {
+				t.Fatalf("expected unexpected status code error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		wts  []WrapTransporter
+		exp  string
+	}{
+		{
+			name: "default",
+			exp:  E2eUserAgent,
+		},
+		{
+			name: "override",
+			wts:  []WrapTransporter{&UserAgentInjector{UserAgent: "alerts-perf"}},
+			exp:  "alerts-perf",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestPrometheusClient(t,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if got := r.UserAgent(); got != tc.exp {
+						t.Errorf("expected User-Agent %q, got %q", tc.exp, got)
+					}
+					_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+				}),
+				tc.wts...,
+			)
+
+			if _, err := c.PrometheusQuery("up"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestTokenFile(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	writeToken := func(token string) {
+		t.Helper()
+		if err := os.WriteFile(tokenFile, []byte(token), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var expected atomic.Value
+	srv := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got := r.Header.Get("Authorization"); got != "Bearer "+expected.Load().(string) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+		}),
+	)
+	t.Cleanup(srv.Close)
+
+	c := NewPrometheusClientWithTokenFile(strings.TrimPrefix(srv.URL, "https://"), tokenFile)
+
+	writeToken("foo")
+	expected.Store("foo")
+	if _, err := c.PrometheusQuery("up"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cached token is rejected after the rotation and the file is read
+	// again for the next request.
+	writeToken("bar")
+	expected.Store("bar")
+	if _, err := c.PrometheusQuery("up"); err == nil {
+		t.Fatal("expected request with the stale token to fail")
+	}
+
+	if _, err := c.PrometheusQuery("up"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnexpectedAlerts(t *testing.T) {
+	body := `
+{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"ALERTS","alertname":"Watchdog","alertstate":"firing","namespace":"openshift-monitoring","severity":"none"},"value":[1551102571.196,"1"]},{"metric":{"__name__":"ALERTS","alertname":"TargetDown","alertstate":"firing","job":"metrics","namespace":"openshift-monitoring","severity":"warning"},"value":[1551102571.196,"1"]},{"metric":{"__name__":"ALERTS","alertname":"KubePodNotReady","alertstate":"firing","namespace":"default","pod":"foo","severity":"warning"},"value":[1551102571.196,"1"]}]}}
+`
+
+	alerts, err := unexpectedAlerts([]byte(body), []string{"Watchdog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := formatAlerts(alerts)
+	exp := `ALERTNAME        NAMESPACE             SEVERITY  LABELS
+KubePodNotReady  default               warning   pod="foo"
+TargetDown       openshift-monitoring  warning   job="metrics"
+`
+	if got != exp {
+		t.Fatalf("expected table:\n%s\ngot:\n%s", exp, got)
+	}
+
+	alerts, err = unexpectedAlerts([]byte(body), []string{"Watchdog", "TargetDown", "KubePodNotReady"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(alerts) != 0 {
+		t.Fatalf("expected no unexpected alerts, got %v", alerts)
+	}
+}
+
+func TestGetAlertmanagerAlertPages(t *testing.T) {
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+	srv.SetResponse(fake.AlertmanagerAlertsPath, `[
+{"labels":{"alertname":"A1"}},
+{"labels":{"alertname":"A2"}},
+{"labels":{"alertname":"A3"}},
+{"labels":{"alertname":"A4"}},
+{"labels":{"alertname":"A5"}}
+]`)
+
+	c := NewPrometheusClient(srv.Host(), "")
+
+	var pages [][]string
+	err := c.GetAlertmanagerAlertPages(
+		AlertmanagerAlertsFilter{
+			Matchers: []string{`severity="critical"`},
+			Receiver: "Default",
+			Silenced: ptr.To(false),
+		},
+		2,
+		func(alerts []json.RawMessage) error {
+			var page []string
+			for _, a := range alerts {
+				var alert struct {
+					Labels map[string]string `json:"labels"`
+				}
+				if err := json.Unmarshal(a, &alert); err != nil {
+					return err
+				}
+				page = append(page, alert.Labels["alertname"])
+			}
+			pages = append(pages, page)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := [][]string{{"A1", "A2"}, {"A3", "A4"}, {"A5"}}
+	if !reflect.DeepEqual(pages, exp) {
+		t.Fatalf("expected pages %v, got %v", exp, pages)
+	}
+
+	q := srv.Requests()[0].Query()
+	for k, v := range map[string]string{
+		"filter":   `severity="critical"`,
+		"receiver": "Default",
+		"silenced": "false",
+	} {
+		if got := q.Get(k); got != v {
+			t.Errorf("expected query parameter %s=%q, got %q", k, v, got)
+		}
+	}
+	if q.Has("active") {
+		t.Errorf("expected no active query parameter, got %q", q.Get("active"))
+	}
+
+	// The iteration stops at the first error.
+	errStop := errors.New("stop")
+	var calls int
+	err = c.GetAlertmanagerAlertPages(AlertmanagerAlertsFilter{}, 2, func([]json.RawMessage) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Fatalf("expected iteration to stop after the first page, got %d calls and error %v", calls, err)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 The Cluster Monitoring Operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fake provides a fake Prometheus (or Thanos Querier) and
+// Alertmanager HTTP API server serving canned responses. It allows testing
+// API clients without a live cluster.
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scenario alters the responses of the server to simulate degraded
+// conditions.
+type Scenario struct {
+	// Delay is added before sending each response.
+	Delay time.Duration
+	// StatusCode, when not zero, makes every request fail with the given
+	// status code.
+	StatusCode int
+	// Warnings are added to the Prometheus API responses, like Thanos
+	// Querier does for partial responses.
+	Warnings []string
+}
+
+// Server is a fake Prometheus and Alertmanager HTTP API server. It listens
+// on HTTPS with a self-signed certificate.
+type Server struct {
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	responses map[string]json.RawMessage
+	scenario  Scenario
+	requests  []*url.URL
+}
+
+// Paths of the API endpoints served by the fake server.
+const (
+	QueryPath              = "/api/v1/query"
+	QueryRangePath         = "/api/v1/query_range"
+	RulesPath              = "/api/v1/rules"
+	AlertsPath             = "/api/v1/alerts"
+	AlertmanagerAlertsPath = "/api/v2/alerts"
+	SilencesPath           = "/api/v2/silences"
+)
+
+// NewServer starts and returns a new Server. By default, the endpoints
+// return empty results. The server should be closed by the caller.
+func NewServer() *Server {
+	s := &Server{
+		responses: map[string]json.RawMessage{
+			QueryPath:              json.RawMessage(`{"resultType":"vector","result":[]}`),
+			QueryRangePath:         json.RawMessage(`{"resultType":"matrix","result":[]}`),
+			RulesPath:              json.RawMessage(`{"groups":[]}`),
+			AlertsPath:             json.RawMessage(`{"alerts":[]}`),
+			AlertmanagerAlertsPath: json.RawMessage(`[]`),
+			SilencesPath:           json.RawMessage(`[]`),
+		},
+	}
+	s.srv = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Host returns the host:port address of the server.
+func (s *Server) Host() string {
+	return strings.TrimPrefix(s.srv.URL, "https://")
+}
+
+// SetResponse configures the response returned for the given path. For the
+// Prometheus API endpoints (/api/v1/*), data is the value of the "data" field
+// of the response. For the Alertmanager API endpoints (/api/v2/*), data is
+// the full response body.
+func (s *Server) SetResponse(path, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses[path] = json.RawMessage(data)
+}
+
+// SetScenario configures the scenario applied to all subsequent requests.
+func (s *Server) SetScenario(sc Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scenario = sc
+}
+
+// Requests returns the URLs of the requests received so far.
+func (s *Server) Requests() []*url.URL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*url.URL(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL)
+	sc := s.scenario
+	data, found := s.responses[r.URL.Path]
+	s.mu.Unlock()
+
+	if sc.Delay > 0 {
+		select {
+		case <-time.After(sc.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	prometheusAPI := strings.HasPrefix(r.URL.Path, "/api/v1/")
+
+	switch {
+	case !found:
+		http.NotFound(w, r)
+	case sc.StatusCode != 0 && prometheusAPI:
+		writeJSON(w, sc.StatusCode, map[string]any{
+			"status":    "error",
+			"errorType": "internal",
+			"error":     http.StatusText(sc.StatusCode),
+		})
+	case sc.StatusCode != 0:
+		http.Error(w, http.StatusText(sc.StatusCode), sc.StatusCode)
+	case prometheusAPI:
+		resp := map[string]any{
+			"status": "success",
+			"data":   data,
+		}
+		if len(sc.Warnings) > 0 {
+			resp["warnings"] = sc.Warnings
+		}
+		writeJSON(w, http.StatusOK, resp)
+	default:
+		writeJSON(w, http.StatusOK, data)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 The Cluster Monitoring Operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func get(ctx context.Context, t *testing.T, s *Server, path string) (int, string, error) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.srv.Client().Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, string(b), nil
+}
+
+func TestServerScenarios(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		scenario Scenario
+		path     string
+		expCode  int
+		expBody  string
+	}{
+		{
+			name:    "default Prometheus response",
+			path:    QueryPath,
+			expCode: http.StatusOK,
+			expBody: `{"data":{"resultType":"vector","result":[]},"status":"success"}` + "\n",
+		},
+		{
+			name:    "default Alertmanager response",
+			path:    AlertmanagerAlertsPath,
+			expCode: http.StatusOK,
+			expBody: "[]\n",
+		},
+		{
+			name:    "unknown path",
+			path:    "/api/v1/unknown",
+			expCode: http.StatusNotFound,
+			expBody: "404 page not found\n",
+		},
+		{
+			name:     "warnings",
+			scenario: Scenario{Warnings: []string{"partial"}},
+			path:     QueryPath,
+			expCode:  http.StatusOK,
+			expBody:  `{"data":{"resultType":"vector","result":[]},"status":"success","warnings":["partial"]}` + "\n",
+		},
+		{
+			name:     "warnings ignored by Alertmanager",
+			scenario: Scenario{Warnings: []string{"partial"}},
+			path:     AlertmanagerAlertsPath,
+			expCode:  http.StatusOK,
+			expBody:  "[]\n",
+		},
+		{
+			name:     "Prometheus error",
+			scenario: Scenario{StatusCode: http.StatusServiceUnavailable},
+			path:     QueryPath,
+			expCode:  http.StatusServiceUnavailable,
+			expBody:  `{"error":"Service Unavailable","errorType":"internal","status":"error"}` + "\n",
+		},
+		{
+			name:     "Alertmanager error",
+			scenario: Scenario{StatusCode: http.StatusServiceUnavailable},
+			path:     AlertmanagerAlertsPath,
+			expCode:  http.StatusServiceUnavailable,
+			expBody:  "Service Unavailable\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewServer()
+			t.Cleanup(s.Close)
+			s.SetScenario(tc.scenario)
+
+			code, body, err := get(context.Background(), t, s, tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if code != tc.expCode {
+				t.Errorf("expected status code %d, got %d", tc.expCode, code)
+			}
+
+			if body != tc.expBody {
+				t.Errorf("expected body %q, got %q", tc.expBody, body)
+			}
+
+			reqs := s.Requests()
+			if len(reqs) != 1 || reqs[0].Path != tc.path {
+				t.Errorf("expected a single request to %s, got %v", tc.path, reqs)
+			}
+		})
+	}
+}
+
+func TestServerDelay(t *testing.T) {
+	const delay = 200 * time.Millisecond
+
+	s := NewServer()
+	t.Cleanup(s.Close)
+	s.SetScenario(Scenario{Delay: delay})
+
+	start := time.Now()
+	code, _, err := get(context.Background(), t, s, QueryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, code)
+	}
+
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Fatalf("expected the response to be delayed by at least %v, got %v", delay, elapsed)
+	}
+
+	// Clients giving up before the end of the delay get no response.
+	ctx, cancel := context.WithTimeout(context.Background(), delay/4)
+	defer cancel()
+
+	if _, _, err := get(ctx, t, s, QueryPath); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+}
@@ -60,6 +60,9 @@ import (
 
 const E2eServiceAccount = "cluster-monitoring-operator-e2e"
 
+// E2eUserAgent is the User-Agent used by the clients of the end-to-end tests.
+const E2eUserAgent = "cluster-monitoring-operator-e2e"
+
 const (
 	namespaceName             = "openshift-monitoring"
 	userWorkloadNamespaceName = "openshift-user-workload-monitoring"
@@ -98,6 +101,7 @@ func New(kubeConfigPath string) (*Framework, CleanUpFunc, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	config.UserAgent = E2eUserAgent
 
 	kubeClient, err := kubernetes.NewForConfig(config)
 	if err != nil {
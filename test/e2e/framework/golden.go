@@ -0,0 +1,177 @@
+// Copyright 2026 The Cluster Monitoring Operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+var updateGoldenFiles = flag.Bool("update-golden", false, "update the golden files instead of comparing against them")
+
+// normalizedValue replaces the values of volatile fields.
+const normalizedValue = "<normalized>"
+
+// volatileFields are the fields of the Prometheus rules/alerts API and
+// Alertmanager alerts API responses which change from one evaluation to
+// another.
+var volatileFields = map[string]struct{}{
+	"activeAt":       {},
+	"endsAt":         {},
+	"evaluationTime": {},
+	"fingerprint":    {},
+	"generatorURL":   {},
+	"lastError":      {},
+	"lastEvaluation": {},
+	"startsAt":       {},
+	"updatedAt":      {},
+	"value":          {},
+}
+
+// volatileListFields are the fields holding lists of identifiers which
+// change from one run to another, like the IDs of the silences and the
+// fingerprints of the inhibiting alerts in the Alertmanager alert status. The
+// items are normalized but the length of the list is kept.
+var volatileListFields = map[string]struct{}{
+	"inhibitedBy": {},
+	"silencedBy":  {},
+}
+
+// opaqueFields are the fields holding user-defined key-value pairs which are
+// never normalized, even if they contain keys named like volatile fields.
+var opaqueFields = map[string]struct{}{
+	"annotations": {},
+	"labels":      {},
+}
+
+// NormalizeAPIResponse returns an indented copy of the JSON response where
+// the values of volatile fields (timestamps, evaluation durations, ...) are
+// replaced by a placeholder and the alerts are sorted, so that the result
+// can be compared against a golden file. Labels and annotations are kept
+// verbatim.
+func NormalizeAPIResponse(body []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+
+	// The Alertmanager API returns a list of alerts at the top-level.
+	v = normalize(v, "alerts")
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func normalize(v any, key string) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		for k := range vv {
+			if _, ok := volatileFields[k]; ok {
+				vv[k] = normalizedValue
+				continue
+			}
+			if _, ok := opaqueFields[k]; ok {
+				continue
+			}
+			if _, ok := volatileListFields[k]; ok {
+				if items, ok := vv[k].([]any); ok {
+					for i := range items {
+						items[i] = normalizedValue
+					}
+					continue
+				}
+			}
+			vv[k] = normalize(vv[k], k)
+		}
+	case []any:
+		for i := range vv {
+			vv[i] = normalize(vv[i], "")
+		}
+
+		// The order of active alerts isn't stable.
+		if key == "alerts" {
+			sortByJSON(vv)
+		}
+	}
+
+	return v
+}
+
+func sortByJSON(items []any) {
+	keys := make([]string, len(items))
+	for i := range items {
+		b, _ := json.Marshal(items[i])
+		keys[i] = string(b)
+	}
+
+	sort.Sort(byKey{items: items, keys: keys})
+}
+
+type byKey struct {
+	items []any
+	keys  []string
+}
+
+func (b byKey) Len() int           { return len(b.items) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.items[i], b.items[j] = b.items[j], b.items[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// AssertGoldenAPIResponse normalizes the API response with
+// NormalizeAPIResponse and compares it with the content of the golden file.
+// When the test runs with the -update-golden flag, the golden file is written
+// instead.
+func AssertGoldenAPIResponse(t *testing.T, goldenFile string, body []byte) {
+	t.Helper()
+
+	got, err := NormalizeAPIResponse(body)
+	if err != nil {
+		t.Fatalf("failed to normalize response %q: %v", ClampMax(body), err)
+	}
+
+	if *updateGoldenFiles {
+		if err := os.MkdirAll(filepath.Dir(goldenFile), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(goldenFile, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	exp, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update-golden to create it): %v", err)
+	}
+
+	if !bytes.Equal(exp, got) {
+		t.Errorf("response doesn't match golden file %s (run with -update-golden to refresh it)\nexpected:\n%s\ngot:\n%s", goldenFile, exp, got)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 The Cluster Monitoring Operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssertGoldenAPIResponse(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		golden string
+		bodies []string
+	}{
+		{
+			name:   "Prometheus rules",
+			golden: "testdata/prometheus-rules.golden.json",
+			bodies: []string{
+				`{"status":"success","data":{"groups":[{"name":"general.rules","file":"/etc/prometheus/rules/general.yaml","rules":[{"state":"firing","name":"Watchdog","query":"vector(1)","duration":0,"labels":{"severity":"none"},"annotations":{},"alerts":[{"labels":{"alertname":"Watchdog","severity":"none"},"annotations":{},"state":"firing","activeAt":"2024-01-01T10:00:00Z","value":"1e+00"}],"health":"ok","evaluationTime":0.000241,"lastEvaluation":"2024-01-01T10:05:00Z","type":"alerting"}],"interval":30,"limit":0,"evaluationTime":0.000312,"lastEvaluation":"2024-01-01T10:05:00Z"}]}}`,
+				`{"status":"success","data":{"groups":[{"name":"general.rules","file":"/etc/prometheus/rules/general.yaml","rules":[{"state":"firing","name":"Watchdog","query":"vector(1)","duration":0,"labels":{"severity":"none"},"annotations":{},"alerts":[{"labels":{"alertname":"Watchdog","severity":"none"},"annotations":{},"state":"firing","activeAt":"2024-03-04T08:00:00Z","value":"1e+00"}],"health":"ok","evaluationTime":0.000198,"lastEvaluation":"2024-03-04T08:30:00Z","type":"alerting"}],"interval":30,"limit":0,"evaluationTime":0.000277,"lastEvaluation":"2024-03-04T08:30:00Z"}]}}`,
+			},
+		},
+		{
+			name:   "Alertmanager alerts",
+			golden: "testdata/alertmanager-alerts.golden.json",
+			bodies: []string{
+				`[{"labels":{"alertname":"Watchdog"},"annotations":{},"startsAt":"2024-01-01T10:00:00Z","endsAt":"2024-01-01T10:10:00Z","updatedAt":"2024-01-01T10:05:00Z","fingerprint":"a","generatorURL":"https://a","receivers":[{"name":"Watchdog"}],"status":{"inhibitedBy":[],"silencedBy":[],"state":"active"}},{"labels":{"alertname":"TargetDown"},"annotations":{},"startsAt":"2024-01-01T10:00:00Z","endsAt":"2024-01-01T10:10:00Z","updatedAt":"2024-01-01T10:05:00Z","fingerprint":"b","generatorURL":"https://b","receivers":[{"name":"Default"}],"status":{"inhibitedBy":[],"silencedBy":[],"state":"active"}},{"labels":{"alertname":"KubePodNotReady","namespace":"default"},"annotations":{},"startsAt":"2024-01-01T10:00:00Z","endsAt":"2024-01-01T10:10:00Z","updatedAt":"2024-01-01T10:05:00Z","fingerprint":"e","generatorURL":"https://e","receivers":[{"name":"Default"}],"status":{"inhibitedBy":[],"silencedBy":["1f4e8b2a-3c5d-4e6f-8a9b-0c1d2e3f4a5b"],"state":"suppressed"}}]`,
+				`[{"labels":{"alertname":"TargetDown"},"annotations":{},"startsAt":"2024-03-04T08:00:00Z","endsAt":"2024-03-04T08:10:00Z","updatedAt":"2024-03-04T08:05:00Z","fingerprint":"c","generatorURL":"https://c","receivers":[{"name":"Default"}],"status":{"inhibitedBy":[],"silencedBy":[],"state":"active"}},{"labels":{"alertname":"Watchdog"},"annotations":{},"startsAt":"2024-03-04T08:00:00Z","endsAt":"2024-03-04T08:10:00Z","updatedAt":"2024-03-04T08:05:00Z","fingerprint":"d","generatorURL":"https://d","receivers":[{"name":"Watchdog"}],"status":{"inhibitedBy":[],"silencedBy":[],"state":"active"}},{"labels":{"alertname":"KubePodNotReady","namespace":"default"},"annotations":{},"startsAt":"2024-03-04T08:00:00Z","endsAt":"2024-03-04T08:10:00Z","updatedAt":"2024-03-04T08:05:00Z","fingerprint":"f","generatorURL":"https://f","receivers":[{"name":"Default"}],"status":{"inhibitedBy":[],"silencedBy":["9a8b7c6d-5e4f-4a3b-9c2d-1e0f9a8b7c6d"],"state":"suppressed"}}]`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Responses which only differ by their volatile fields and
+			// ordering match the same golden file.
+			for _, body := range tc.bodies {
+				AssertGoldenAPIResponse(t, tc.golden, []byte(body))
+			}
+		})
+	}
+}
+
+func TestNormalizeAPIResponseKeepsLabels(t *testing.T) {
+	body := `[{"labels":{"alertname":"Foo","value":"high","fingerprint":"abc"},"annotations":{"startsAt":"maintenance"},"startsAt":"2024-01-01T10:00:00Z"}]`
+
+	got, err := NormalizeAPIResponse([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{
+		`"value": "high"`,
+		`"fingerprint": "abc"`,
+		`"startsAt": "maintenance"`,
+		`"startsAt": "<normalized>"`,
+	} {
+		if !strings.Contains(string(got), exp) {
+			t.Errorf("expected normalized response to contain %s, got:\n%s", exp, got)
+		}
+	}
+}
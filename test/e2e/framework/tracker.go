@@ -0,0 +1,185 @@
+// Copyright 2026 The Cluster Monitoring Operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	osmv1 "github.com/openshift/api/monitoring/v1"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceTracker records the PrometheusRules, AlertRelabelConfigs and
+// Alertmanager silences created during a test and removes them when the test
+// completes, so that tests don't leak objects into each other.
+type ResourceTracker struct {
+	f *Framework
+
+	mu       sync.Mutex
+	cleanups []func(context.Context) error
+}
+
+// NewResourceTracker returns a ResourceTracker which deletes the tracked
+// resources in the reverse order of their creation when the test and all its
+// subtests have completed.
+func (f *Framework) NewResourceTracker(t *testing.T) *ResourceTracker {
+	t.Helper()
+
+	rt := &ResourceTracker{f: f}
+	t.Cleanup(func() {
+		for _, err := range rt.cleanUp(context.Background()) {
+			t.Errorf("failed to clean up tracked resource: %v", err)
+		}
+	})
+
+	return rt
+}
+
+func (rt *ResourceTracker) track(fn func(context.Context) error) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.cleanups = append(rt.cleanups, fn)
+}
+
+func (rt *ResourceTracker) cleanUp(ctx context.Context) []error {
+	rt.mu.Lock()
+	cleanups := rt.cleanups
+	rt.cleanups = nil
+	rt.mu.Unlock()
+
+	var errs []error
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		if err := cleanups[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// TrackPrometheusRule registers an existing PrometheusRule for deletion.
+func (rt *ResourceTracker) TrackPrometheusRule(namespace, name string) {
+	rt.track(func(ctx context.Context) error {
+		err := rt.f.MonitoringClient.PrometheusRules(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("deleting PrometheusRule %s/%s: %w", namespace, name, err)
+		}
+		return nil
+	})
+}
+
+// CreatePrometheusRule creates the given PrometheusRule and registers it for
+// deletion.
+func (rt *ResourceTracker) CreatePrometheusRule(ctx context.Context, pr *monitoringv1.PrometheusRule) (*monitoringv1.PrometheusRule, error) {
+	ensureCreatedByTestLabel(pr)
+
+	pr, err := rt.f.MonitoringClient.PrometheusRules(pr.Namespace).Create(ctx, pr, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	rt.TrackPrometheusRule(pr.Namespace, pr.Name)
+
+	return pr, nil
+}
+
+// TrackAlertRelabelConfig registers an existing AlertRelabelConfig for
+// deletion.
+func (rt *ResourceTracker) TrackAlertRelabelConfig(namespace, name string) {
+	rt.track(func(ctx context.Context) error {
+		err := rt.f.OpenShiftMonitoringClient.MonitoringV1().AlertRelabelConfigs(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("deleting AlertRelabelConfig %s/%s: %w", namespace, name, err)
+		}
+		return nil
+	})
+}
+
+// CreateAlertRelabelConfig creates the given AlertRelabelConfig and registers
+// it for deletion.
+func (rt *ResourceTracker) CreateAlertRelabelConfig(ctx context.Context, arc *osmv1.AlertRelabelConfig) (*osmv1.AlertRelabelConfig, error) {
+	ensureCreatedByTestLabel(arc)
+
+	arc, err := rt.f.OpenShiftMonitoringClient.MonitoringV1().AlertRelabelConfigs(arc.Namespace).Create(ctx, arc, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	rt.TrackAlertRelabelConfig(arc.Namespace, arc.Name)
+
+	return arc, nil
+}
+
+// TrackSilence registers an existing Alertmanager silence for expiration.
+// The client must be allowed to expire silences.
+func (rt *ResourceTracker) TrackSilence(c *PrometheusClient, id string) {
+	rt.track(func(context.Context) error {
+		resp, err := c.Do("DELETE", fmt.Sprintf("/api/v2/silence/%s", id), nil)
+		if err != nil {
+			return fmt.Errorf("expiring silence %s: %w", id, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+
+		// The test may have expired the silence already in which case
+		// Alertmanager returns a 500 error.
+		b, _ := io.ReadAll(resp.Body)
+		if strings.Contains(string(b), "already expired") {
+			return nil
+		}
+
+		return fmt.Errorf("expiring silence %s: unexpected status code %d (%q)", id, resp.StatusCode, ClampMax(b))
+	})
+}
+
+// CreateSilence creates an Alertmanager silence from the given JSON payload
+// and registers it for expiration. It returns the silence ID.
+func (rt *ResourceTracker) CreateSilence(c *PrometheusClient, silence []byte) (string, error) {
+	resp, err := c.Do("POST", "/api/v2/silences", silence)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code response, want %d, got %d (%q)", http.StatusOK, resp.StatusCode, ClampMax(b))
+	}
+
+	var created struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := json.Unmarshal(b, &created); err != nil {
+		return "", fmt.Errorf("parsing response %q: %w", ClampMax(b), err)
+	}
+	rt.TrackSilence(c, created.SilenceID)
+
+	return created.SilenceID, nil
+}
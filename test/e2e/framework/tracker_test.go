@@ -0,0 +1,276 @@
+// Copyright 2026 The Cluster Monitoring Operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	openshiftmonitoringclientset "github.com/openshift/client-go/monitoring/clientset/versioned"
+	monClient "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned/typed/monitoring/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestResourceTrackerCleanUp(t *testing.T) {
+	var (
+		rt    ResourceTracker
+		calls []string
+	)
+	for _, name := range []string{"first", "second", "third"} {
+		rt.track(func(context.Context) error {
+			calls = append(calls, name)
+			if name == "third" {
+				return nil
+			}
+			return errors.New(name)
+		})
+	}
+
+	errs := rt.cleanUp(context.Background())
+
+	if exp := []string{"third", "second", "first"}; !reflect.DeepEqual(calls, exp) {
+		t.Fatalf("expected cleanups to run in order %v, got %v", exp, calls)
+	}
+
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	if exp := []string{"second", "first"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected errors %v, got %v", exp, got)
+	}
+
+	// The cleanups run only once.
+	calls = nil
+	if errs := rt.cleanUp(context.Background()); len(errs) != 0 || len(calls) != 0 {
+		t.Fatalf("expected no cleanup on second call, got %d calls and errors %v", len(calls), errs)
+	}
+}
+
+func TestNewResourceTracker(t *testing.T) {
+	var calls []string
+	t.Run("subtest", func(t *testing.T) {
+		rt := (&Framework{}).NewResourceTracker(t)
+		for _, name := range []string{"first", "second"} {
+			rt.track(func(context.Context) error {
+				calls = append(calls, name)
+				return nil
+			})
+		}
+
+		if len(calls) != 0 {
+			t.Fatalf("expected no cleanup before the end of the test, got %v", calls)
+		}
+	})
+
+	if exp := []string{"second", "first"}; !reflect.DeepEqual(calls, exp) {
+		t.Fatalf("expected cleanups to run in order %v at the end of the test, got %v", exp, calls)
+	}
+}
+
+// newTestKubeFramework returns a Framework whose monitoring clients talk to
+// a fake API server answering DELETE requests with the status code
+// configured for the request's path (200 by default). It also returns the
+// paths of the received requests.
+func newTestKubeFramework(t *testing.T, statusCodes map[string]int) (*Framework, func() []string) {
+	t.Helper()
+
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+
+		code, found := statusCodes[r.URL.Path]
+		if !found {
+			code = http.StatusOK
+		}
+
+		status := "Success"
+		reason := ""
+		switch code {
+		case http.StatusOK:
+		case http.StatusNotFound:
+			status, reason = "Failure", "NotFound"
+		default:
+			status, reason = "Failure", "InternalError"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		fmt.Fprintf(w, `{"kind":"Status","apiVersion":"v1","status":%q,"reason":%q,"code":%d}`, status, reason, code)
+	}))
+	t.Cleanup(srv.Close)
+
+	config := &rest.Config{Host: srv.URL}
+
+	mClient, err := monClient.NewForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	osmClient, err := openshiftmonitoringclientset.NewForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &Framework{
+		MonitoringClient:          mClient,
+		OpenShiftMonitoringClient: osmClient,
+	}
+
+	return f, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), paths...)
+	}
+}
+
+func TestResourceTrackerKubernetesResources(t *testing.T) {
+	const (
+		rulePath        = "/apis/monitoring.coreos.com/v1/namespaces/ns1/prometheusrules/rule"
+		missingRulePath = "/apis/monitoring.coreos.com/v1/namespaces/ns1/prometheusrules/missing"
+		brokenRulePath  = "/apis/monitoring.coreos.com/v1/namespaces/ns1/prometheusrules/broken"
+		arcPath         = "/apis/monitoring.openshift.io/v1/namespaces/ns2/alertrelabelconfigs/arc"
+		missingARCPath  = "/apis/monitoring.openshift.io/v1/namespaces/ns2/alertrelabelconfigs/missing"
+	)
+
+	f, requests := newTestKubeFramework(t, map[string]int{
+		missingRulePath: http.StatusNotFound,
+		brokenRulePath:  http.StatusInternalServerError,
+		missingARCPath:  http.StatusNotFound,
+	})
+
+	rt := &ResourceTracker{f: f}
+	rt.TrackPrometheusRule("ns1", "rule")
+	rt.TrackPrometheusRule("ns1", "missing")
+	rt.TrackPrometheusRule("ns1", "broken")
+	rt.TrackAlertRelabelConfig("ns2", "arc")
+	rt.TrackAlertRelabelConfig("ns2", "missing")
+
+	errs := rt.cleanUp(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "deleting PrometheusRule ns1/broken") {
+		t.Fatalf("expected a single error for the broken PrometheusRule, got %v", errs)
+	}
+
+	exp := []string{missingARCPath, arcPath, brokenRulePath, missingRulePath, rulePath}
+	if got := requests(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected requests %v, got %v", exp, got)
+	}
+}
+
+func TestResourceTrackerSilences(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		statusCode int
+		body       string
+		expErr     bool
+	}{
+		{
+			name:       "expired",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "not found",
+			statusCode: http.StatusNotFound,
+			body:       "silence not found",
+		},
+		{
+			name:       "already expired",
+			statusCode: http.StatusInternalServerError,
+			body:       "silence abc already expired",
+		},
+		{
+			name:       "internal error",
+			statusCode: http.StatusInternalServerError,
+			body:       "internal error",
+			expErr:     true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var deleted []string
+			c := newTestPrometheusClient(t,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch {
+					case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+						_, _ = w.Write([]byte(`{"silenceID":"abc"}`))
+					case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v2/silence/"):
+						deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/api/v2/silence/"))
+						w.WriteHeader(tc.statusCode)
+						_, _ = w.Write([]byte(tc.body))
+					default:
+						t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+						w.WriteHeader(http.StatusBadRequest)
+					}
+				}),
+			)
+
+			rt := &ResourceTracker{}
+			id, err := rt.CreateSilence(c, []byte(`{}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if id != "abc" {
+				t.Fatalf("expected silence ID %q, got %q", "abc", id)
+			}
+
+			errs := rt.cleanUp(context.Background())
+			if tc.expErr != (len(errs) > 0) {
+				t.Fatalf("expected error %v, got %v", tc.expErr, errs)
+			}
+
+			if exp := []string{"abc"}; !reflect.DeepEqual(deleted, exp) {
+				t.Fatalf("expected silences %v to be expired, got %v", exp, deleted)
+			}
+		})
+	}
+}
+
+func TestResourceTrackerCreateSilenceError(t *testing.T) {
+	c := newTestPrometheusClient(t,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			http.Error(w, "invalid silence", http.StatusBadRequest)
+		}),
+	)
+
+	rt := &ResourceTracker{}
+	if _, err := rt.CreateSilence(c, []byte(`{}`)); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	// A silence which failed to be created isn't tracked.
+	if errs := rt.cleanUp(context.Background()); len(errs) != 0 {
+		t.Fatalf("expected no cleanup error, got %v", errs)
+	}
+}